@@ -0,0 +1,65 @@
+// Command dfs-csi-controller runs the DFS CSI driver's Controller service
+// only, for deployment as a single-replica Controller Deployment alongside
+// external-provisioner/external-attacher/external-resizer/external-snapshotter
+// sidecars.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver"
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver/server"
+)
+
+func main() {
+	var (
+		endpoint     = flag.String("endpoint", "unix:///tmp/csi.sock", "CSI endpoint")
+		storageNodes = flag.String("storage-nodes", "", "comma-separated host:port list of storage-node /nodes endpoints to poll for topology")
+		pollInterval = flag.Duration("heartbeat-interval", 10*time.Second, "how often to poll storage nodes for their heartbeat")
+		metricsAddr  = flag.String("metrics-addr", "", "address to serve Prometheus metrics on (e.g. :9090); disabled if empty")
+	)
+	flag.Parse()
+
+	drv, err := driver.NewDFSDriver(driver.ModeController, "", *endpoint, false)
+	if err != nil {
+		fmt.Printf("Failed to create driver: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", server.MetricsHandler())
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				fmt.Printf("Metrics server exited: %s\n", err)
+			}
+		}()
+	}
+
+	stopHeartbeats := make(chan struct{})
+	if *storageNodes != "" {
+		go drv.Controller().PollNodes(strings.Split(*storageNodes, ","), *pollInterval, stopHeartbeats)
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-c
+		close(stopHeartbeats)
+		drv.Stop()
+		os.Exit(0)
+	}()
+
+	if err := drv.Run(); err != nil {
+		fmt.Printf("Failed to run driver: %s\n", err)
+		os.Exit(1)
+	}
+}
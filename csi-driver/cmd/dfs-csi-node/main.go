@@ -0,0 +1,61 @@
+// Command dfs-csi-node runs the DFS CSI driver's Node service only, for
+// deployment as a Node DaemonSet alongside the node-driver-registrar
+// sidecar.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver"
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver/server"
+)
+
+func main() {
+	var (
+		endpoint    = flag.String("endpoint", "unix:///tmp/csi.sock", "CSI endpoint")
+		nodeID      = flag.String("nodeid", "", "node id")
+		ephemeral   = flag.Bool("ephemeral", false, "enable CSI ephemeral inline volumes")
+		metricsAddr = flag.String("metrics-addr", "", "address to serve Prometheus metrics on (e.g. :9090); disabled if empty")
+	)
+	flag.Parse()
+
+	if *nodeID == "" {
+		fmt.Println("node id is required")
+		os.Exit(1)
+	}
+
+	drv, err := driver.NewDFSDriver(driver.ModeNode, *nodeID, *endpoint, *ephemeral)
+	if err != nil {
+		fmt.Printf("Failed to create driver: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", server.MetricsHandler())
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				fmt.Printf("Metrics server exited: %s\n", err)
+			}
+		}()
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-c
+		drv.Stop()
+		os.Exit(0)
+	}()
+
+	if err := drv.Run(); err != nil {
+		fmt.Printf("Failed to run driver: %s\n", err)
+		os.Exit(1)
+	}
+}
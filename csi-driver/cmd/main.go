@@ -1,19 +1,28 @@
+// Command csi-driver runs the DFS CSI driver with both the Controller and
+// Node services registered in a single process, for local development and
+// single-node deployments. Production clusters should instead run
+// dfs-csi-controller and dfs-csi-node as separate Deployment/DaemonSet
+// processes.
 package main
 
 import (
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver"
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver/server"
 )
 
 func main() {
 	var (
-		endpoint = flag.String("endpoint", "unix:///tmp/csi.sock", "CSI endpoint")
-		nodeID   = flag.String("nodeid", "", "node id")
+		endpoint    = flag.String("endpoint", "unix:///tmp/csi.sock", "CSI endpoint")
+		nodeID      = flag.String("nodeid", "", "node id")
+		ephemeral   = flag.Bool("ephemeral", false, "enable CSI ephemeral inline volumes")
+		metricsAddr = flag.String("metrics-addr", "", "address to serve Prometheus metrics on (e.g. :9090); disabled if empty")
 	)
 	flag.Parse()
 
@@ -22,12 +31,22 @@ func main() {
 		os.Exit(1)
 	}
 
-	drv, err := driver.NewDFSDriver(*nodeID, *endpoint)
+	drv, err := driver.NewDFSDriver(driver.ModeAll, *nodeID, *endpoint, *ephemeral)
 	if err != nil {
 		fmt.Printf("Failed to create driver: %s\n", err)
 		os.Exit(1)
 	}
 
+	if *metricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", server.MetricsHandler())
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				fmt.Printf("Metrics server exited: %s\n", err)
+			}
+		}()
+	}
+
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
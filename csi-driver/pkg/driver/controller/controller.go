@@ -0,0 +1,395 @@
+// Package controller implements the CSI ControllerServer for the DFS
+// driver: volume and snapshot lifecycle management against the shared
+// backing store at /var/lib/dfs. It holds no node-local mount state, so it
+// can run standalone as a Deployment while pkg/driver/node runs as a
+// DaemonSet with different RBAC and mount privileges.
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver/locks"
+)
+
+// volumeOperationAlreadyExistsErrorMsg is returned when a CSI RPC finds that
+// another operation for the same volume ID is already in flight, matching
+// the wording other CSI drivers (e.g. ceph-csi) use for this case.
+const volumeOperationAlreadyExistsErrorMsg = "an operation with the given volume %s already exists"
+
+// volumesDir is the shared backing store for volume data; every node in the
+// cluster reaches the same path, so Node derives a volume's location from
+// its ID by convention rather than querying the controller for it. It's a
+// var rather than a const so tests can point it at a temporary directory
+// instead of the real production path.
+var volumesDir = "/var/lib/dfs/volumes"
+
+// Volume is a provisioned unit of storage.
+type Volume struct {
+	VolID      string
+	VolName    string
+	VolSize    int64
+	VolPath    string
+	NodeID     string
+	AccessMode csi.VolumeCapability_AccessMode_Mode
+}
+
+// Server implements csi.ControllerServer, the only component in the DFS CSI
+// driver that owns the volume and snapshot registries.
+type Server struct {
+	volumes map[string]*Volume
+	volLock sync.RWMutex
+
+	snapshots map[string]*Snapshot
+	snapLock  sync.RWMutex
+
+	// volumeLocks serializes CSI RPCs that mutate a given volume name/ID so
+	// that retries from external-provisioner/attacher/resizer can't race.
+	volumeLocks *locks.VolumeLocks
+	// idLocker lets read-only RPCs for a volume run concurrently with each
+	// other while still excluding an in-flight mutator of that volume.
+	idLocker *locks.IDLocker
+
+	// nodes is the topology registry: which storage nodes are reachable and
+	// their last-reported free capacity, kept current by heartbeats. See
+	// topology.go.
+	nodes     map[string]*NodeInfo
+	nodesLock sync.RWMutex
+}
+
+// NewServer returns an empty controller Server.
+func NewServer() *Server {
+	return &Server{
+		volumes:     make(map[string]*Volume),
+		snapshots:   make(map[string]*Snapshot),
+		volumeLocks: locks.NewVolumeLocks(),
+		idLocker:    locks.NewIDLocker(),
+		nodes:       make(map[string]*NodeInfo),
+	}
+}
+
+func (s *Server) CreateVolume(ctx context.Context, req *csi.CreateVolumeRequest) (*csi.CreateVolumeResponse, error) {
+	// Validate request
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume name is required")
+	}
+	if req.VolumeCapabilities == nil || len(req.VolumeCapabilities) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Volume capabilities are required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.Name) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.Name)
+	}
+	defer s.volumeLocks.Release(req.Name)
+
+	s.volLock.Lock()
+	defer s.volLock.Unlock()
+
+	// Check if volume already exists
+	for _, vol := range s.volumes {
+		if vol.VolName == req.Name {
+			// Volume already exists: return it as-is; its size and
+			// capabilities are not compared against this request.
+			return &csi.CreateVolumeResponse{
+				Volume: &csi.Volume{
+					VolumeId:      vol.VolID,
+					CapacityBytes: vol.VolSize,
+					VolumeContext: req.Parameters,
+				},
+			}, nil
+		}
+	}
+
+	// Create new volume
+	volID := uuid.New().String()
+	volPath := filepath.Join(volumesDir, volID)
+
+	// Create volume directory
+	if err := os.MkdirAll(volPath, 0750); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create volume directory: %s", err)
+	}
+
+	// Materialize content from a snapshot or an existing volume, if requested.
+	if source := req.GetVolumeContentSource(); source != nil {
+		switch {
+		case source.GetSnapshot() != nil:
+			if err := s.restoreSnapshot(source.GetSnapshot().GetSnapshotId(), volPath); err != nil {
+				os.RemoveAll(volPath)
+				return nil, err
+			}
+		case source.GetVolume() != nil:
+			if err := s.cloneVolumeLocked(source.GetVolume().GetVolumeId(), volPath); err != nil {
+				os.RemoveAll(volPath)
+				return nil, err
+			}
+		}
+	}
+
+	// Calculate volume size
+	var volSize int64 = 1 * 1024 * 1024 * 1024 // Default 1GB
+	if req.CapacityRange != nil && req.CapacityRange.RequiredBytes > 0 {
+		volSize = req.CapacityRange.RequiredBytes
+	}
+
+	nodeID, err := s.chooseNode(req.GetAccessibilityRequirements())
+	if err != nil {
+		os.RemoveAll(volPath)
+		return nil, err
+	}
+
+	// Store volume metadata
+	vol := &Volume{
+		VolID:      volID,
+		VolName:    req.Name,
+		VolSize:    volSize,
+		VolPath:    volPath,
+		NodeID:     nodeID,
+		AccessMode: req.VolumeCapabilities[0].GetAccessMode().GetMode(),
+	}
+	s.volumes[volID] = vol
+
+	return &csi.CreateVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:           volID,
+			CapacityBytes:      volSize,
+			VolumeContext:      req.Parameters,
+			ContentSource:      req.GetVolumeContentSource(),
+			AccessibleTopology: accessibleTopology(nodeID),
+		},
+	}, nil
+}
+
+func (s *Server) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
+	if req.VolumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID is required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.VolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+	}
+	defer s.volumeLocks.Release(req.VolumeId)
+
+	s.idLocker.Lock(req.VolumeId)
+	defer s.idLocker.Unlock(req.VolumeId)
+
+	s.volLock.Lock()
+	defer s.volLock.Unlock()
+
+	vol, exists := s.volumes[req.VolumeId]
+	if !exists {
+		// Volume already deleted or doesn't exist
+		return &csi.DeleteVolumeResponse{}, nil
+	}
+
+	// The controller has no visibility into node-local mounts once split
+	// across processes; the external-attacher is responsible for driving
+	// ControllerUnpublishVolume before DeleteVolume is called.
+
+	// Delete volume directory
+	if err := os.RemoveAll(vol.VolPath); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to delete volume directory: %s", err)
+	}
+
+	// Remove volume from map
+	delete(s.volumes, req.VolumeId)
+
+	return &csi.DeleteVolumeResponse{}, nil
+}
+
+func (s *Server) ControllerPublishVolume(ctx context.Context, req *csi.ControllerPublishVolumeRequest) (*csi.ControllerPublishVolumeResponse, error) {
+	return &csi.ControllerPublishVolumeResponse{}, nil
+}
+
+func (s *Server) ControllerUnpublishVolume(ctx context.Context, req *csi.ControllerUnpublishVolumeRequest) (*csi.ControllerUnpublishVolumeResponse, error) {
+	return &csi.ControllerUnpublishVolumeResponse{}, nil
+}
+
+func (s *Server) ValidateVolumeCapabilities(ctx context.Context, req *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
+	if req.VolumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID is required")
+	}
+
+	s.idLocker.RLock(req.VolumeId)
+	defer s.idLocker.RUnlock(req.VolumeId)
+
+	s.volLock.RLock()
+	_, exists := s.volumes[req.VolumeId]
+	s.volLock.RUnlock()
+
+	if !exists {
+		return nil, status.Error(codes.NotFound, "Volume not found")
+	}
+
+	// Check each capability
+	for _, cap := range req.VolumeCapabilities {
+		switch cap.GetAccessType().(type) {
+		case *csi.VolumeCapability_Mount:
+			// We support mount volumes
+		default:
+			return &csi.ValidateVolumeCapabilitiesResponse{
+				Message: "Unsupported access type",
+			}, nil
+		}
+
+		// Check access mode
+		switch cap.GetAccessMode().GetMode() {
+		case csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+			csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+			csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+			csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY:
+			// These modes are supported; SINGLE_NODE_MULTI_WRITER matches the
+			// SINGLE_NODE_MULTI_WRITER capability NodeGetCapabilities advertises.
+		default:
+			return &csi.ValidateVolumeCapabilitiesResponse{
+				Message: "Unsupported access mode",
+			}, nil
+		}
+	}
+
+	return &csi.ValidateVolumeCapabilitiesResponse{
+		Confirmed: &csi.ValidateVolumeCapabilitiesResponse_Confirmed{
+			VolumeCapabilities: req.VolumeCapabilities,
+		},
+	}, nil
+}
+
+func (s *Server) ListVolumes(ctx context.Context, req *csi.ListVolumesRequest) (*csi.ListVolumesResponse, error) {
+	return &csi.ListVolumesResponse{}, nil
+}
+
+func (s *Server) GetCapacity(ctx context.Context, req *csi.GetCapacityRequest) (*csi.GetCapacityResponse, error) {
+	return &csi.GetCapacityResponse{
+		AvailableCapacity: s.capacityForTopology(req.GetAccessibleTopology()),
+	}, nil
+}
+
+func (s *Server) ControllerGetCapabilities(ctx context.Context, req *csi.ControllerGetCapabilitiesRequest) (*csi.ControllerGetCapabilitiesResponse, error) {
+	return &csi.ControllerGetCapabilitiesResponse{
+		Capabilities: []*csi.ControllerServiceCapability{
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_LIST_VOLUMES,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_GET_CAPACITY,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_CREATE_DELETE_SNAPSHOT,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_LIST_SNAPSHOTS,
+					},
+				},
+			},
+			{
+				Type: &csi.ControllerServiceCapability_Rpc{
+					Rpc: &csi.ControllerServiceCapability_RPC{
+						Type: csi.ControllerServiceCapability_RPC_CLONE_VOLUME,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *Server) ControllerExpandVolume(ctx context.Context, req *csi.ControllerExpandVolumeRequest) (*csi.ControllerExpandVolumeResponse, error) {
+	if req.VolumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID is required")
+	}
+
+	if req.CapacityRange == nil {
+		return nil, status.Error(codes.InvalidArgument, "Capacity range is required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.VolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+	}
+	defer s.volumeLocks.Release(req.VolumeId)
+
+	s.idLocker.Lock(req.VolumeId)
+	defer s.idLocker.Unlock(req.VolumeId)
+
+	s.volLock.Lock()
+	vol, exists := s.volumes[req.VolumeId]
+	if !exists {
+		s.volLock.Unlock()
+		return nil, status.Error(codes.NotFound, "Volume not found")
+	}
+
+	// Update volume size
+	vol.VolSize = req.CapacityRange.RequiredBytes
+	s.volumes[req.VolumeId] = vol
+	s.volLock.Unlock()
+
+	return &csi.ControllerExpandVolumeResponse{
+		CapacityBytes:         req.CapacityRange.RequiredBytes,
+		NodeExpansionRequired: true,
+	}, nil
+}
+
+func (s *Server) ControllerGetVolume(ctx context.Context, req *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
+	s.idLocker.RLock(req.VolumeId)
+	defer s.idLocker.RUnlock(req.VolumeId)
+
+	s.volLock.RLock()
+	vol, exists := s.volumes[req.VolumeId]
+	s.volLock.RUnlock()
+
+	if !exists {
+		return nil, status.Error(codes.NotFound, "Volume not found")
+	}
+
+	return &csi.ControllerGetVolumeResponse{
+		Volume: &csi.Volume{
+			VolumeId:      vol.VolID,
+			CapacityBytes: vol.VolSize,
+		},
+		Status: &csi.ControllerGetVolumeResponse_VolumeStatus{
+			PublishedNodeIds: []string{vol.NodeID},
+		},
+	}, nil
+}
+
+// CreateSnapshot, DeleteSnapshot, and ListSnapshots are implemented in
+// snapshot.go.
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func mountCapability() *csi.VolumeCapability {
+	return &csi.VolumeCapability{
+		AccessType: &csi.VolumeCapability_Mount{
+			Mount: &csi.VolumeCapability_MountVolume{
+				MountFlags: []string{"rw"},
+			},
+		},
+		AccessMode: &csi.VolumeCapability_AccessMode{
+			Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		},
+	}
+}
+
+func TestCreateAndDeleteVolume(t *testing.T) {
+	s := NewServer()
+
+	resp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-a",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	volID := resp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, volID)) })
+
+	if _, err := os.Stat(filepath.Join(volumesDir, volID)); err != nil {
+		t.Fatalf("expected volume directory to exist: %v", err)
+	}
+
+	if _, err := s.DeleteVolume(context.Background(), &csi.DeleteVolumeRequest{VolumeId: volID}); err != nil {
+		t.Fatalf("DeleteVolume: %v", err)
+	}
+
+	s.volLock.RLock()
+	_, exists := s.volumes[volID]
+	s.volLock.RUnlock()
+	if exists {
+		t.Fatal("expected volume to be removed after DeleteVolume")
+	}
+}
+
+// TestCreateVolumeConcurrentSameName drives concurrent CreateVolume RPCs for
+// the same volume name through volumeLocks. volumeLocks.TryAcquire only
+// excludes *overlapping* calls: once the winner releases the name lock,
+// every later goroutine legitimately takes it, finds the volume already
+// exists by name, and returns CSI's idempotent success response rather than
+// Aborted. So for fast in-process calls we can't assert exactly one success
+// — we assert every call either succeeds (all agreeing on the same volume
+// ID) or loses the race against an overlapping call and sees Aborted,
+// matching what an external-provisioner retry storm against a single PVC
+// would see.
+func TestCreateVolumeConcurrentSameName(t *testing.T) {
+	s := NewServer()
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	var successVolIDs []string
+	var aborted int
+
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			resp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+				Name:               "vol-concurrent",
+				VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+			})
+
+			mux.Lock()
+			defer mux.Unlock()
+			switch {
+			case err == nil:
+				successVolIDs = append(successVolIDs, resp.Volume.VolumeId)
+			case status.Code(err) == codes.Aborted:
+				aborted++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(successVolIDs) == 0 {
+		t.Fatal("expected at least 1 CreateVolume to succeed")
+	}
+	for _, id := range successVolIDs {
+		if id != successVolIDs[0] {
+			t.Fatalf("expected every successful call to return the same volume ID, got %v", successVolIDs)
+		}
+	}
+	if len(successVolIDs)+aborted != goroutines {
+		t.Fatalf("expected every call to either succeed or be Aborted, got %d successes and %d aborted out of %d", len(successVolIDs), aborted, goroutines)
+	}
+
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, successVolIDs[0])) })
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// nodeHeartbeat mirrors the JSON payload storage-node's /nodes endpoint
+// returns.
+type nodeHeartbeat struct {
+	NodeID    string `json:"node_id"`
+	FreeBytes int64  `json:"free_bytes"`
+}
+
+// PollNodes periodically polls each storage node's /nodes endpoint and
+// refreshes the topology registry with its reported capacity. It runs until
+// stopCh is closed, and is meant to be started in its own goroutine by the
+// controller entrypoint.
+func (s *Server) PollNodes(addrs []string, interval time.Duration, stopCh <-chan struct{}) {
+	client := &http.Client{Timeout: interval}
+
+	poll := func() {
+		for _, addr := range addrs {
+			hb, err := fetchHeartbeat(client, addr)
+			if err != nil {
+				log.Printf("heartbeat poll of %s failed: %v", addr, err)
+				continue
+			}
+			s.RegisterNode(hb.NodeID, hb.FreeBytes)
+		}
+	}
+
+	poll()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			poll()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func fetchHeartbeat(client *http.Client, addr string) (*nodeHeartbeat, error) {
+	resp, err := client.Get("http://" + addr + "/nodes")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var hb nodeHeartbeat
+	if err := json.NewDecoder(resp.Body).Decode(&hb); err != nil {
+		return nil, err
+	}
+	return &hb, nil
+}
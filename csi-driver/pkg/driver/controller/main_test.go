@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain points volumesDir and snapshotsDir at temporary directories for
+// the duration of the package's tests, so `go test` never touches the real
+// production paths under /var/lib/dfs and two test binaries running in
+// parallel can't collide on the same volume-ID-derived path.
+func TestMain(m *testing.M) {
+	volDir, err := os.MkdirTemp("", "dfs-controller-volumes-")
+	if err != nil {
+		panic(err)
+	}
+	volumesDir = volDir
+
+	snapDir, err := os.MkdirTemp("", "dfs-controller-snapshots-")
+	if err != nil {
+		panic(err)
+	}
+	snapshotsDir = snapDir
+
+	code := m.Run()
+
+	os.RemoveAll(volDir)
+	os.RemoveAll(snapDir)
+	os.Exit(code)
+}
@@ -0,0 +1,313 @@
+package controller
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// snapshotsDir is where snapshot payloads are persisted, mirroring the
+// layout of /var/lib/dfs/volumes used for volumes themselves. It's a var
+// rather than a const so tests can point it at a temporary directory instead
+// of the real production path.
+var snapshotsDir = "/var/lib/dfs/snapshots"
+
+// Snapshot is a point-in-time, read-only copy of a volume's contents.
+type Snapshot struct {
+	SnapID       string
+	SourceVolID  string
+	Name         string
+	SizeBytes    int64
+	CreationTime time.Time
+	ReadyToUse   bool
+}
+
+func (s *Server) CreateSnapshot(ctx context.Context, req *csi.CreateSnapshotRequest) (*csi.CreateSnapshotResponse, error) {
+	if req.SourceVolumeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Source volume ID is required")
+	}
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot name is required")
+	}
+
+	// Serialize concurrent snapshot creation against the same source volume.
+	if !s.volumeLocks.TryAcquire(req.SourceVolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.SourceVolumeId)
+	}
+	defer s.volumeLocks.Release(req.SourceVolumeId)
+
+	s.volLock.RLock()
+	vol, exists := s.volumes[req.SourceVolumeId]
+	s.volLock.RUnlock()
+	if !exists {
+		return nil, status.Error(codes.NotFound, "Source volume not found")
+	}
+
+	s.snapLock.Lock()
+	for _, snap := range s.snapshots {
+		if snap.Name == req.Name {
+			s.snapLock.Unlock()
+			if snap.SourceVolID != req.SourceVolumeId {
+				return nil, status.Errorf(codes.AlreadyExists, "Snapshot %s already exists for a different source volume", req.Name)
+			}
+			return snapshotResponse(snap), nil
+		}
+	}
+	s.snapLock.Unlock()
+
+	if err := os.MkdirAll(snapshotsDir, 0750); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create snapshots directory: %s", err)
+	}
+
+	snapID := uuid.New().String()
+	if err := tarDirectory(vol.VolPath, filepath.Join(snapshotsDir, snapID)); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to snapshot volume %s: %s", req.SourceVolumeId, err)
+	}
+
+	snap := &Snapshot{
+		SnapID:       snapID,
+		SourceVolID:  req.SourceVolumeId,
+		Name:         req.Name,
+		SizeBytes:    vol.VolSize,
+		CreationTime: time.Now(),
+		ReadyToUse:   true,
+	}
+
+	s.snapLock.Lock()
+	s.snapshots[snapID] = snap
+	s.snapLock.Unlock()
+
+	return snapshotResponse(snap), nil
+}
+
+func (s *Server) DeleteSnapshot(ctx context.Context, req *csi.DeleteSnapshotRequest) (*csi.DeleteSnapshotResponse, error) {
+	if req.SnapshotId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Snapshot ID is required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.SnapshotId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.SnapshotId)
+	}
+	defer s.volumeLocks.Release(req.SnapshotId)
+
+	s.snapLock.Lock()
+	defer s.snapLock.Unlock()
+
+	snap, exists := s.snapshots[req.SnapshotId]
+	if !exists {
+		// Snapshot already deleted or doesn't exist.
+		return &csi.DeleteSnapshotResponse{}, nil
+	}
+
+	if err := os.RemoveAll(filepath.Join(snapshotsDir, snap.SnapID)); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to delete snapshot: %s", err)
+	}
+
+	delete(s.snapshots, req.SnapshotId)
+
+	return &csi.DeleteSnapshotResponse{}, nil
+}
+
+func (s *Server) ListSnapshots(ctx context.Context, req *csi.ListSnapshotsRequest) (*csi.ListSnapshotsResponse, error) {
+	s.snapLock.RLock()
+	defer s.snapLock.RUnlock()
+
+	entries := make([]*csi.ListSnapshotsResponse_Entry, 0, len(s.snapshots))
+	for _, snap := range s.snapshots {
+		if req.SnapshotId != "" && snap.SnapID != req.SnapshotId {
+			continue
+		}
+		if req.SourceVolumeId != "" && snap.SourceVolID != req.SourceVolumeId {
+			continue
+		}
+		entries = append(entries, &csi.ListSnapshotsResponse_Entry{Snapshot: toCSISnapshot(snap)})
+	}
+
+	return &csi.ListSnapshotsResponse{Entries: entries}, nil
+}
+
+func snapshotResponse(snap *Snapshot) *csi.CreateSnapshotResponse {
+	return &csi.CreateSnapshotResponse{Snapshot: toCSISnapshot(snap)}
+}
+
+func toCSISnapshot(snap *Snapshot) *csi.Snapshot {
+	return &csi.Snapshot{
+		SnapshotId:     snap.SnapID,
+		SourceVolumeId: snap.SourceVolID,
+		SizeBytes:      snap.SizeBytes,
+		CreationTime:   timestamppb.New(snap.CreationTime),
+		ReadyToUse:     snap.ReadyToUse,
+	}
+}
+
+// restoreSnapshot extracts a snapshot's tar payload into destPath, used by
+// CreateVolume to materialize a volume from a CONTENT_SOURCE_SNAPSHOT.
+func (s *Server) restoreSnapshot(snapID, destPath string) error {
+	s.snapLock.RLock()
+	snap, exists := s.snapshots[snapID]
+	s.snapLock.RUnlock()
+	if !exists {
+		return status.Errorf(codes.NotFound, "Snapshot %s not found", snapID)
+	}
+
+	if err := untarFile(filepath.Join(snapshotsDir, snap.SnapID), destPath); err != nil {
+		return status.Errorf(codes.Internal, "Failed to restore snapshot %s: %s", snapID, err)
+	}
+	return nil
+}
+
+// cloneVolumeLocked copies a source volume's contents into destPath, used by
+// CreateVolume to materialize a volume from a CLONE_VOLUME content source.
+// The caller must already hold s.volLock, which CreateVolume does while
+// provisioning the new volume.
+func (s *Server) cloneVolumeLocked(srcVolID, destPath string) error {
+	src, exists := s.volumes[srcVolID]
+	if !exists {
+		return status.Errorf(codes.NotFound, "Source volume %s not found", srcVolID)
+	}
+
+	if err := copyDirectory(src.VolPath, destPath); err != nil {
+		return status.Errorf(codes.Internal, "Failed to clone volume %s: %s", srcVolID, err)
+	}
+	return nil
+}
+
+// tarDirectory writes a tar stream of srcDir's contents to destFile.
+func tarDirectory(srcDir, destFile string) error {
+	out, err := os.Create(destFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	tw := tar.NewWriter(out)
+	defer tw.Close()
+
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+// untarFile extracts the tar stream in srcFile into destDir, which must
+// already exist.
+func untarFile(srcFile, destDir string) error {
+	in, err := os.Open(srcFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tr := tar.NewReader(in)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(destDir, hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+				return err
+			}
+			if err := writeTarFile(target, tr, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeTarFile(target string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// copyDirectory recursively copies srcDir into destDir, hardlinking files
+// where the destination is on the same filesystem and falling back to a
+// byte copy otherwise (e.g. across filesystem boundaries).
+func copyDirectory(srcDir, destDir string) error {
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, rel)
+		if rel == "." {
+			return os.MkdirAll(target, info.Mode())
+		}
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return err
+		}
+		if err := os.Link(path, target); err == nil {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		return writeTarFile(target, src, info.Mode())
+	})
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+func TestCreateSnapshotAndRestore(t *testing.T) {
+	s := NewServer()
+
+	createResp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-src",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	srcVolID := createResp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, srcVolID)) })
+
+	srcVol := s.volumes[srcVolID]
+	if err := os.WriteFile(filepath.Join(srcVol.VolPath, "data.txt"), []byte("hello"), 0640); err != nil {
+		t.Fatalf("seed source volume: %v", err)
+	}
+
+	snapResp, err := s.CreateSnapshot(context.Background(), &csi.CreateSnapshotRequest{
+		SourceVolumeId: srcVolID,
+		Name:           "snap-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	snapID := snapResp.Snapshot.SnapshotId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(snapshotsDir, snapID)) })
+
+	list, err := s.ListSnapshots(context.Background(), &csi.ListSnapshotsRequest{SourceVolumeId: srcVolID})
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(list.Entries) != 1 || list.Entries[0].Snapshot.SnapshotId != snapID {
+		t.Fatalf("expected snapshot %s listed, got %+v", snapID, list.Entries)
+	}
+
+	restoreResp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-from-snap",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+		VolumeContentSource: &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Snapshot{
+				Snapshot: &csi.VolumeContentSource_SnapshotSource{SnapshotId: snapID},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume from snapshot: %v", err)
+	}
+	restoredVolID := restoreResp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, restoredVolID)) })
+
+	got, err := os.ReadFile(filepath.Join(s.volumes[restoredVolID].VolPath, "data.txt"))
+	if err != nil {
+		t.Fatalf("read restored file: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("expected restored content %q, got %q", "hello", got)
+	}
+
+	if _, err := s.DeleteSnapshot(context.Background(), &csi.DeleteSnapshotRequest{SnapshotId: snapID}); err != nil {
+		t.Fatalf("DeleteSnapshot: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(snapshotsDir, snapID)); !os.IsNotExist(err) {
+		t.Fatalf("expected snapshot payload to be removed, got err=%v", err)
+	}
+}
+
+func TestCreateVolumeFromCloneSource(t *testing.T) {
+	s := NewServer()
+
+	srcResp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-clone-src",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	srcVolID := srcResp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, srcVolID)) })
+
+	srcVol := s.volumes[srcVolID]
+	if err := os.WriteFile(filepath.Join(srcVol.VolPath, "data.txt"), []byte("clone-me"), 0640); err != nil {
+		t.Fatalf("seed source volume: %v", err)
+	}
+
+	cloneResp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-clone",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+		VolumeContentSource: &csi.VolumeContentSource{
+			Type: &csi.VolumeContentSource_Volume{
+				Volume: &csi.VolumeContentSource_VolumeSource{VolumeId: srcVolID},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume from clone: %v", err)
+	}
+	cloneVolID := cloneResp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, cloneVolID)) })
+
+	got, err := os.ReadFile(filepath.Join(s.volumes[cloneVolID].VolPath, "data.txt"))
+	if err != nil {
+		t.Fatalf("read cloned file: %v", err)
+	}
+	if string(got) != "clone-me" {
+		t.Fatalf("expected cloned content %q, got %q", "clone-me", got)
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hostnameTopologyKey is the well-known topology segment key this driver
+// uses, matching the one NodeGetInfo advertises in pkg/driver/node.
+const hostnameTopologyKey = "kubernetes.io/hostname"
+
+// NodeInfo is what the controller knows about a storage node's
+// reachability and free capacity, refreshed by its periodic heartbeat.
+type NodeInfo struct {
+	NodeID    string
+	FreeBytes int64
+	LastSeen  time.Time
+}
+
+// RegisterNode records or refreshes a heartbeat from a storage node. It is
+// called by the heartbeat poller that talks to each node's /nodes endpoint
+// (see storage-node/cmd).
+func (s *Server) RegisterNode(nodeID string, freeBytes int64) {
+	s.nodesLock.Lock()
+	defer s.nodesLock.Unlock()
+	s.nodes[nodeID] = &NodeInfo{
+		NodeID:    nodeID,
+		FreeBytes: freeBytes,
+		LastSeen:  time.Now(),
+	}
+}
+
+// chooseNode picks the hostname CreateVolume should pin a new volume to,
+// per topo: the first Preferred segment that names a known node, else the
+// first Requisite segment that names a known node. If topo is nil, no
+// topology was requested and the volume is left unpinned. If topo is
+// non-nil but no candidate names a known node, provisioning cannot be
+// satisfied.
+func (s *Server) chooseNode(topo *csi.TopologyRequirement) (string, error) {
+	if topo == nil {
+		return "", nil
+	}
+
+	s.nodesLock.RLock()
+	defer s.nodesLock.RUnlock()
+
+	if hostname, ok := s.firstKnownHostnameLocked(topo.GetPreferred()); ok {
+		return hostname, nil
+	}
+	if hostname, ok := s.firstKnownHostnameLocked(topo.GetRequisite()); ok {
+		return hostname, nil
+	}
+
+	return "", status.Error(codes.ResourceExhausted, "no accessible node satisfies the requested topology")
+}
+
+// firstKnownHostnameLocked returns the first hostname among segments that is
+// present in s.nodes. Callers must hold s.nodesLock for reading.
+func (s *Server) firstKnownHostnameLocked(segments []*csi.Topology) (string, bool) {
+	for _, seg := range segments {
+		hostname := seg.GetSegments()[hostnameTopologyKey]
+		if hostname == "" {
+			continue
+		}
+		if _, known := s.nodes[hostname]; known {
+			return hostname, true
+		}
+	}
+	return "", false
+}
+
+// capacityForTopology sums the free capacity of nodes matching topo's
+// segments, or of every known node if topo is nil.
+func (s *Server) capacityForTopology(topo *csi.Topology) int64 {
+	s.nodesLock.RLock()
+	defer s.nodesLock.RUnlock()
+
+	hostname := topo.GetSegments()[hostnameTopologyKey]
+
+	var total int64
+	for _, n := range s.nodes {
+		if hostname != "" && n.NodeID != hostname {
+			continue
+		}
+		total += n.FreeBytes
+	}
+	return total
+}
+
+// accessibleTopology builds the CSI topology list to echo back in a
+// CreateVolumeResponse; it is empty when the volume wasn't pinned to a node.
+func accessibleTopology(nodeID string) []*csi.Topology {
+	if nodeID == "" {
+		return nil
+	}
+	return []*csi.Topology{
+		{Segments: map[string]string{hostnameTopologyKey: nodeID}},
+	}
+}
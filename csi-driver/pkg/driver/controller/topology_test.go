@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func topologyFor(hostname string) *csi.Topology {
+	return &csi.Topology{Segments: map[string]string{hostnameTopologyKey: hostname}}
+}
+
+func TestCreateVolumePinsToPreferredNode(t *testing.T) {
+	s := NewServer()
+	s.RegisterNode("node-a", 100)
+	s.RegisterNode("node-b", 200)
+
+	resp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-topo",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+		AccessibilityRequirements: &csi.TopologyRequirement{
+			Preferred: []*csi.Topology{topologyFor("node-b")},
+			Requisite: []*csi.Topology{topologyFor("node-a"), topologyFor("node-b")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	volID := resp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, volID)) })
+
+	if len(resp.Volume.AccessibleTopology) != 1 || resp.Volume.AccessibleTopology[0].Segments[hostnameTopologyKey] != "node-b" {
+		t.Fatalf("expected volume pinned to node-b, got %+v", resp.Volume.AccessibleTopology)
+	}
+}
+
+func TestCreateVolumeFallsBackToRequisiteNode(t *testing.T) {
+	s := NewServer()
+	s.RegisterNode("node-a", 100)
+
+	resp, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-topo-fallback",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+		AccessibilityRequirements: &csi.TopologyRequirement{
+			Preferred: []*csi.Topology{topologyFor("node-unknown")},
+			Requisite: []*csi.Topology{topologyFor("node-a")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateVolume: %v", err)
+	}
+	volID := resp.Volume.VolumeId
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(volumesDir, volID)) })
+
+	if len(resp.Volume.AccessibleTopology) != 1 || resp.Volume.AccessibleTopology[0].Segments[hostnameTopologyKey] != "node-a" {
+		t.Fatalf("expected volume pinned to node-a, got %+v", resp.Volume.AccessibleTopology)
+	}
+}
+
+func TestCreateVolumeResourceExhaustedWhenNoNodeMatches(t *testing.T) {
+	s := NewServer()
+	s.RegisterNode("node-a", 100)
+
+	_, err := s.CreateVolume(context.Background(), &csi.CreateVolumeRequest{
+		Name:               "vol-topo-unsatisfiable",
+		VolumeCapabilities: []*csi.VolumeCapability{mountCapability()},
+		AccessibilityRequirements: &csi.TopologyRequirement{
+			Requisite: []*csi.Topology{topologyFor("node-unknown")},
+		},
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestGetCapacityAggregatesByTopology(t *testing.T) {
+	s := NewServer()
+	s.RegisterNode("node-a", 100)
+	s.RegisterNode("node-b", 250)
+
+	all, err := s.GetCapacity(context.Background(), &csi.GetCapacityRequest{})
+	if err != nil {
+		t.Fatalf("GetCapacity: %v", err)
+	}
+	if all.AvailableCapacity != 350 {
+		t.Fatalf("expected aggregate capacity 350, got %d", all.AvailableCapacity)
+	}
+
+	scoped, err := s.GetCapacity(context.Background(), &csi.GetCapacityRequest{
+		AccessibleTopology: topologyFor("node-b"),
+	})
+	if err != nil {
+		t.Fatalf("GetCapacity (scoped): %v", err)
+	}
+	if scoped.AvailableCapacity != 250 {
+		t.Fatalf("expected scoped capacity 250, got %d", scoped.AvailableCapacity)
+	}
+}
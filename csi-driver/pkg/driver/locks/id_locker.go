@@ -0,0 +1,51 @@
+package locks
+
+import "sync"
+
+// IDLocker hands out a per-id sync.RWMutex so that read-only RPCs
+// (ValidateVolumeCapabilities, ControllerGetVolume, NodeGetVolumeStats, ...)
+// for a given volume can run concurrently with each other while still being
+// excluded by any in-flight mutator of that same volume.
+type IDLocker struct {
+	mux   sync.Mutex
+	locks map[string]*sync.RWMutex
+}
+
+// NewIDLocker returns an empty IDLocker.
+func NewIDLocker() *IDLocker {
+	return &IDLocker{
+		locks: make(map[string]*sync.RWMutex),
+	}
+}
+
+func (l *IDLocker) lockFor(id string) *sync.RWMutex {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	rw, ok := l.locks[id]
+	if !ok {
+		rw = &sync.RWMutex{}
+		l.locks[id] = rw
+	}
+	return rw
+}
+
+// RLock acquires a read lock for id. Call RUnlock to release it.
+func (l *IDLocker) RLock(id string) {
+	l.lockFor(id).RLock()
+}
+
+// RUnlock releases a read lock previously acquired with RLock.
+func (l *IDLocker) RUnlock(id string) {
+	l.lockFor(id).RUnlock()
+}
+
+// Lock acquires a write lock for id. Call Unlock to release it.
+func (l *IDLocker) Lock(id string) {
+	l.lockFor(id).Lock()
+}
+
+// Unlock releases a write lock previously acquired with Lock.
+func (l *IDLocker) Unlock(id string) {
+	l.lockFor(id).Unlock()
+}
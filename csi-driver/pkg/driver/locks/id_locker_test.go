@@ -0,0 +1,66 @@
+package locks
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIDLockerWriterExcludesReader(t *testing.T) {
+	l := NewIDLocker()
+
+	l.Lock("vol-1")
+
+	rlockAcquired := make(chan struct{})
+	go func() {
+		l.RLock("vol-1")
+		close(rlockAcquired)
+		l.RUnlock("vol-1")
+	}()
+
+	select {
+	case <-rlockAcquired:
+		t.Fatal("expected RLock to block while a writer holds the lock")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	l.Unlock("vol-1")
+
+	select {
+	case <-rlockAcquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected RLock to proceed after writer released the lock")
+	}
+}
+
+func TestIDLockerReadersRunConcurrently(t *testing.T) {
+	l := NewIDLocker()
+
+	const readers = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+
+	wg.Add(readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			defer wg.Done()
+			<-start
+			l.RLock("vol-1")
+			defer l.RUnlock("vol-1")
+		}()
+	}
+
+	close(start)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected concurrent RLock calls to all complete without blocking on each other")
+	}
+}
@@ -0,0 +1,49 @@
+package locks
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestVolumeLocksConcurrentAcquire(t *testing.T) {
+	vl := NewVolumeLocks()
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	var successes int32
+	var mux sync.Mutex
+
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if vl.TryAcquire("same-volume") {
+				mux.Lock()
+				successes++
+				mux.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes != 1 {
+		t.Fatalf("expected exactly 1 goroutine to acquire the lock, got %d", successes)
+	}
+}
+
+func TestVolumeLocksReleaseAllowsReacquire(t *testing.T) {
+	vl := NewVolumeLocks()
+
+	if !vl.TryAcquire("vol-1") {
+		t.Fatal("expected first acquire to succeed")
+	}
+	if vl.TryAcquire("vol-1") {
+		t.Fatal("expected second acquire to fail while lock is held")
+	}
+
+	vl.Release("vol-1")
+
+	if !vl.TryAcquire("vol-1") {
+		t.Fatal("expected acquire to succeed after release")
+	}
+}
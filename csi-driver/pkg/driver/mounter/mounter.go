@@ -0,0 +1,66 @@
+// Package mounter implements node-local mount helpers for the CSI driver,
+// including detection of a corrupted bind mount left behind when the
+// underlying mount is torn down out-of-band (kubelet restart, backing
+// process killed, container-terminated fuse daemon).
+package mounter
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// IsCorruptedMountError reports whether err, as returned by Statfs against a
+// mount point, indicates the mount is corrupt rather than simply absent:
+// ENOTCONN (backing connection dropped), ESTALE (stale filesystem handle),
+// or EIO (I/O against it fails outright).
+func IsCorruptedMountError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, syscall.ENOTCONN) ||
+		errors.Is(err, syscall.ESTALE) ||
+		errors.Is(err, syscall.EIO)
+}
+
+// Interface is implemented by anything that can bind-mount, unmount, and
+// probe a volume's mount point; pkg/driver/node depends on this rather than
+// on NodeMounter directly so tests can substitute a fake.
+type Interface interface {
+	Mount(source string, target string, fstype string, opts []string) error
+	Unmount(target string) error
+	// Statfs probes the filesystem mounted at target, returning the raw
+	// error so callers can detect a corrupted mount via
+	// IsCorruptedMountError.
+	Statfs(target string) error
+}
+
+// NodeMounter is the production Interface implementation, backed by real
+// bind mounts and Statfs syscalls.
+type NodeMounter struct{}
+
+// Mount bind-mounts source onto target, passing opts through as mount(8)
+// options alongside "bind" (e.g. "ro" produces "-o bind,ro").
+func (NodeMounter) Mount(source, target, fstype string, opts []string) error {
+	return exec.Command("mount", mountArgs(source, target, opts)...).Run()
+}
+
+// mountArgs builds the argv for a bind mount, split out from Mount so tests
+// can assert on it without shelling out.
+func mountArgs(source, target string, opts []string) []string {
+	mountOpts := append([]string{"bind"}, opts...)
+	return []string{"-o", strings.Join(mountOpts, ","), source, target}
+}
+
+// Unmount unmounts target.
+func (NodeMounter) Unmount(target string) error {
+	return exec.Command("umount", target).Run()
+}
+
+// Statfs probes the filesystem mounted at target, surfacing the raw syscall
+// error so callers can pass it to IsCorruptedMountError.
+func (NodeMounter) Statfs(target string) error {
+	var stat syscall.Statfs_t
+	return syscall.Statfs(target, &stat)
+}
@@ -0,0 +1,65 @@
+package mounter
+
+import (
+	"errors"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestIsCorruptedMountError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"ENOTCONN", syscall.ENOTCONN, true},
+		{"ESTALE", syscall.ESTALE, true},
+		{"EIO", syscall.EIO, true},
+		{"ENOENT", syscall.ENOENT, false},
+		{"ENOTCONN message text only", errors.New("statfs: " + syscall.ENOTCONN.Error()), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsCorruptedMountError(tc.err); got != tc.want {
+				t.Errorf("IsCorruptedMountError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsCorruptedMountErrorWrapped(t *testing.T) {
+	wrapped := &wrapError{syscall.ESTALE}
+	if !IsCorruptedMountError(wrapped) {
+		t.Error("expected wrapped ESTALE to be detected via errors.Is")
+	}
+}
+
+type wrapError struct {
+	err error
+}
+
+func (w *wrapError) Error() string { return "wrapped: " + w.err.Error() }
+func (w *wrapError) Unwrap() error { return w.err }
+
+func TestMountArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []string
+		want []string
+	}{
+		{"rw", nil, []string{"-o", "bind", "/src", "/dst"}},
+		{"readonly", []string{"ro"}, []string{"-o", "bind,ro", "/src", "/dst"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := mountArgs("/src", "/dst", tc.opts)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("mountArgs(%q) = %v, want %v", tc.opts, got, tc.want)
+			}
+		})
+	}
+}
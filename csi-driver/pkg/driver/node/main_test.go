@@ -0,0 +1,23 @@
+package node
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain points volumesDir at a temporary directory for the duration of
+// the package's tests, so `go test` never touches the real production path
+// under /var/lib/dfs and two test binaries running in parallel can't
+// collide on the same volume-ID-derived path.
+func TestMain(m *testing.M) {
+	volDir, err := os.MkdirTemp("", "dfs-node-volumes-")
+	if err != nil {
+		panic(err)
+	}
+	volumesDir = volDir
+
+	code := m.Run()
+
+	os.RemoveAll(volDir)
+	os.Exit(code)
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+func TestNodePublishVolumeRemountsCorruptedMount(t *testing.T) {
+	s, fm := newTestServer(false)
+
+	volID := "vol-corrupt"
+	volPath := volumePath(volID)
+	if err := os.MkdirAll(volPath, 0750); err != nil {
+		t.Fatalf("seed volume directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(volPath) })
+
+	target := t.TempDir()
+	if _, err := s.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+		VolumeId:   volID,
+		TargetPath: target,
+	}); err != nil {
+		t.Fatalf("NodePublishVolume: %v", err)
+	}
+
+	// Simulate the bind mount being torn down out-of-band.
+	fm.statfsErr[target] = syscall.ENOTCONN
+	delete(fm.mounted, target)
+
+	if _, err := s.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+		VolumeId:   volID,
+		TargetPath: target,
+	}); err != nil {
+		t.Fatalf("NodePublishVolume (remount): %v", err)
+	}
+
+	if _, remounted := fm.mounted[target]; !remounted {
+		t.Fatal("expected corrupted mount to be remounted")
+	}
+}
+
+func TestNodeStageVolumeRemountsCorruptedMount(t *testing.T) {
+	s, fm := newTestServer(false)
+
+	volID := "vol-stage-corrupt"
+	volPath := volumePath(volID)
+	if err := os.MkdirAll(volPath, 0750); err != nil {
+		t.Fatalf("seed volume directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(volPath) })
+
+	staging := t.TempDir()
+	if _, err := s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          volID,
+		StagingTargetPath: staging,
+	}); err != nil {
+		t.Fatalf("NodeStageVolume: %v", err)
+	}
+
+	fm.statfsErr[staging] = syscall.ESTALE
+	delete(fm.mounted, staging)
+
+	if _, err := s.NodeStageVolume(context.Background(), &csi.NodeStageVolumeRequest{
+		VolumeId:          volID,
+		StagingTargetPath: staging,
+	}); err != nil {
+		t.Fatalf("NodeStageVolume (remount): %v", err)
+	}
+
+	if _, remounted := fm.mounted[staging]; !remounted {
+		t.Fatal("expected corrupted staged mount to be remounted")
+	}
+}
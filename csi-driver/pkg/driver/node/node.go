@@ -0,0 +1,414 @@
+// Package node implements the CSI NodeServer for the DFS driver: staging,
+// publishing, and unpublishing volumes on the host the Node process runs on.
+// It holds no volume registry of its own. Since the node runs as a separate
+// process (and typically a separate DaemonSet Pod) from pkg/driver/controller,
+// it cannot read the controller's in-memory volume map; instead it locates a
+// volume's data by the same /var/lib/dfs/volumes/<id> convention the
+// controller uses when it provisions one, since both share the same backing
+// store.
+package node
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver/locks"
+	"github.com/kevinklatman/DistributedFileSystem/csi-driver/pkg/driver/mounter"
+)
+
+// volumeOperationAlreadyExistsErrorMsg is returned when a CSI RPC finds that
+// another operation for the same volume ID is already in flight, matching
+// the wording other CSI drivers (e.g. ceph-csi) use for this case.
+const volumeOperationAlreadyExistsErrorMsg = "an operation with the given volume %s already exists"
+
+// ephemeralContextKey is the well-known VolumeContext key kubelet sets on
+// NodePublishVolumeRequest for CSI ephemeral inline volumes.
+const ephemeralContextKey = "csi.storage.k8s.io/ephemeral"
+
+// volumesDir is the shared backing store for volume data. It must match
+// pkg/driver/controller's volumesDir: both processes address the same
+// cluster-shared filesystem by the same volume ID convention. It's a var
+// rather than a const so tests can point it at a temporary directory instead
+// of the real production path.
+var volumesDir = "/var/lib/dfs/volumes"
+
+// volumePath returns the conventional on-disk location of a volume's data.
+func volumePath(volID string) string {
+	return filepath.Join(volumesDir, volID)
+}
+
+// Mount records a bind mount the node has made for a volume.
+type Mount struct {
+	VolID      string
+	TargetPath string
+	FSType     string
+	ReadOnly   bool
+	// Ephemeral marks a mount created inline by NodePublishVolume without a
+	// prior CreateVolume call; NodeUnpublishVolume must tear down the
+	// backing volume it owns, not just the bind mount.
+	Ephemeral bool
+}
+
+// Server implements csi.NodeServer.
+type Server struct {
+	nodeID string
+
+	mounter mounter.Interface
+
+	// ephemeral enables CSI ephemeral inline volumes: when true, every
+	// NodePublishVolume call for an unknown volume ID provisions one on the
+	// fly instead of requiring a prior CreateVolume.
+	ephemeral bool
+
+	mounts    map[string]*Mount
+	mountLock sync.RWMutex
+
+	// volumeLocks serializes CSI RPCs that mutate a given volume ID so that
+	// retries from kubelet can't race.
+	volumeLocks *locks.VolumeLocks
+	// idLocker lets read-only RPCs for a volume run concurrently with each
+	// other while still excluding an in-flight mutator of that volume.
+	idLocker *locks.IDLocker
+}
+
+// NewServer returns a node Server that mounts volumes using m.
+func NewServer(nodeID string, ephemeral bool, m mounter.Interface) *Server {
+	return &Server{
+		nodeID:      nodeID,
+		mounter:     m,
+		ephemeral:   ephemeral,
+		mounts:      make(map[string]*Mount),
+		volumeLocks: locks.NewVolumeLocks(),
+		idLocker:    locks.NewIDLocker(),
+	}
+}
+
+func (s *Server) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
+	if req.VolumeId == "" || req.StagingTargetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID and staging target path are required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.VolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+	}
+	defer s.volumeLocks.Release(req.VolumeId)
+
+	s.idLocker.Lock(req.VolumeId)
+	defer s.idLocker.Unlock(req.VolumeId)
+
+	volPath := volumePath(req.VolumeId)
+	if _, err := os.Stat(volPath); err != nil {
+		return nil, status.Error(codes.NotFound, "Volume not found")
+	}
+
+	s.mountLock.Lock()
+	defer s.mountLock.Unlock()
+
+	// Already staged: probe for a corrupted mount left behind by an
+	// out-of-band teardown (kubelet restart, killed backing process, ...)
+	// and repair it before returning.
+	if staged, ok := s.mounts[req.StagingTargetPath]; ok {
+		if err := s.remountIfCorrupted(req.StagingTargetPath, volPath, staged.ReadOnly); err != nil {
+			return nil, err
+		}
+		return &csi.NodeStageVolumeResponse{}, nil
+	}
+
+	if err := os.MkdirAll(req.StagingTargetPath, 0750); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create staging directory: %s", err)
+	}
+
+	if err := s.mounter.Mount(volPath, req.StagingTargetPath, "bind", nil); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to stage volume: %s", err)
+	}
+
+	s.mounts[req.StagingTargetPath] = &Mount{
+		VolID:      req.VolumeId,
+		TargetPath: req.StagingTargetPath,
+	}
+
+	return &csi.NodeStageVolumeResponse{}, nil
+}
+
+func (s *Server) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
+	if req.VolumeId == "" || req.StagingTargetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID and staging target path are required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.VolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+	}
+	defer s.volumeLocks.Release(req.VolumeId)
+
+	s.idLocker.Lock(req.VolumeId)
+	defer s.idLocker.Unlock(req.VolumeId)
+
+	s.mountLock.Lock()
+	defer s.mountLock.Unlock()
+
+	if _, staged := s.mounts[req.StagingTargetPath]; !staged {
+		return &csi.NodeUnstageVolumeResponse{}, nil
+	}
+
+	if err := s.mounter.Unmount(req.StagingTargetPath); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to unstage volume: %s", err)
+	}
+
+	delete(s.mounts, req.StagingTargetPath)
+
+	return &csi.NodeUnstageVolumeResponse{}, nil
+}
+
+// remountIfCorrupted probes the mount at target and, if it is corrupt (the
+// backing bind mount was torn down out-of-band), unmounts and re-mounts it
+// from source.
+func (s *Server) remountIfCorrupted(target, source string, readonly bool) error {
+	if err := s.mounter.Statfs(target); mounter.IsCorruptedMountError(err) {
+		_ = s.mounter.Unmount(target) // best-effort; target is already broken
+
+		mountFlags := []string{}
+		if readonly {
+			mountFlags = append(mountFlags, "ro")
+		}
+		if err := s.mounter.Mount(source, target, "bind", mountFlags); err != nil {
+			return status.Errorf(codes.Internal, "Failed to remount corrupted volume: %s", err)
+		}
+	}
+	return nil
+}
+
+func (s *Server) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+	if req.VolumeId == "" || req.TargetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID and target path are required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.VolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+	}
+	defer s.volumeLocks.Release(req.VolumeId)
+
+	s.idLocker.Lock(req.VolumeId)
+	defer s.idLocker.Unlock(req.VolumeId)
+
+	ephemeral := s.ephemeral || req.GetVolumeContext()[ephemeralContextKey] == "true"
+
+	volPath := volumePath(req.VolumeId)
+	provisioned := false
+	if _, err := os.Stat(volPath); err != nil {
+		if !ephemeral {
+			return nil, status.Error(codes.NotFound, "Volume not found")
+		}
+		if err := s.createEphemeralVolume(req, volPath); err != nil {
+			return nil, err
+		}
+		provisioned = true
+	}
+
+	s.mountLock.Lock()
+	defer s.mountLock.Unlock()
+
+	// Check if already mounted; if so, make sure the mount wasn't torn down
+	// out-of-band before trusting our in-memory state.
+	for _, mount := range s.mounts {
+		if mount.VolID == req.VolumeId && mount.TargetPath == req.TargetPath {
+			if err := s.remountIfCorrupted(req.TargetPath, volPath, mount.ReadOnly); err != nil {
+				return nil, err
+			}
+			return &csi.NodePublishVolumeResponse{}, nil
+		}
+	}
+
+	// Create target directory if it doesn't exist
+	if err := os.MkdirAll(req.TargetPath, 0750); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create target directory: %s", err)
+	}
+
+	// Mount the volume
+	mountFlags := []string{}
+	if req.Readonly {
+		mountFlags = append(mountFlags, "ro")
+	}
+
+	if err := s.mounter.Mount(volPath, req.TargetPath, "bind", mountFlags); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to mount volume: %s", err)
+	}
+
+	// Record mount
+	s.mounts[req.TargetPath] = &Mount{
+		VolID:      req.VolumeId,
+		TargetPath: req.TargetPath,
+		ReadOnly:   req.Readonly,
+		Ephemeral:  provisioned,
+	}
+
+	return &csi.NodePublishVolumeResponse{}, nil
+}
+
+// createEphemeralVolume provisions a volume inline at volPath for a CSI
+// ephemeral inline volume request, which arrives without a prior
+// CreateVolume call against the controller.
+func (s *Server) createEphemeralVolume(req *csi.NodePublishVolumeRequest, volPath string) error {
+	// The size parameter is accepted for parity with the controller-provisioned
+	// path, but ephemeral volumes are plain bind-mounted directories with no
+	// capacity enforcement.
+	if sizeStr := req.GetVolumeContext()["size"]; sizeStr != "" {
+		if _, err := strconv.ParseInt(sizeStr, 10, 64); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid size %q: %s", sizeStr, err)
+		}
+	}
+
+	if err := os.MkdirAll(volPath, 0750); err != nil {
+		return status.Errorf(codes.Internal, "Failed to create ephemeral volume directory: %s", err)
+	}
+	return nil
+}
+
+func (s *Server) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
+	if req.VolumeId != "" {
+		if !s.volumeLocks.TryAcquire(req.VolumeId) {
+			return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+		}
+		defer s.volumeLocks.Release(req.VolumeId)
+
+		s.idLocker.Lock(req.VolumeId)
+		defer s.idLocker.Unlock(req.VolumeId)
+	}
+
+	s.mountLock.Lock()
+	defer s.mountLock.Unlock()
+
+	// Check if volume is mounted
+	for _, mount := range s.mounts {
+		if mount.TargetPath == req.TargetPath {
+			// Unmount the volume
+			if err := s.mounter.Unmount(req.TargetPath); err != nil {
+				return nil, status.Errorf(codes.Internal, "Failed to unmount volume: %s", err)
+			}
+
+			// Remove mount record
+			delete(s.mounts, req.TargetPath)
+
+			if mount.Ephemeral {
+				if err := os.RemoveAll(volumePath(mount.VolID)); err != nil {
+					return nil, status.Errorf(codes.Internal, "Failed to delete ephemeral volume directory: %s", err)
+				}
+			}
+
+			return &csi.NodeUnpublishVolumeResponse{}, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.NotFound, "Volume not found")
+}
+
+func (s *Server) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
+	if req.VolumeId == "" || req.VolumePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID and volume path are required")
+	}
+
+	s.idLocker.RLock(req.VolumeId)
+	defer s.idLocker.RUnlock(req.VolumeId)
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(req.VolumePath, &stat); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to get volume stats: %v", err)
+	}
+
+	available := stat.Bavail * uint64(stat.Bsize)
+	total := stat.Blocks * uint64(stat.Bsize)
+	used := (stat.Blocks - stat.Bfree) * uint64(stat.Bsize)
+
+	return &csi.NodeGetVolumeStatsResponse{
+		Usage: []*csi.VolumeUsage{
+			{
+				Available: int64(available),
+				Total:     int64(total),
+				Used:      int64(used),
+				Unit:      csi.VolumeUsage_BYTES,
+			},
+			{
+				Available: int64(stat.Ffree),
+				Total:     int64(stat.Files),
+				Used:      int64(stat.Files - stat.Ffree),
+				Unit:      csi.VolumeUsage_INODES,
+			},
+		},
+	}, nil
+}
+
+func (s *Server) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
+	if req.VolumeId == "" || req.VolumePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "Volume ID and volume path are required")
+	}
+
+	if !s.volumeLocks.TryAcquire(req.VolumeId) {
+		return nil, status.Errorf(codes.Aborted, volumeOperationAlreadyExistsErrorMsg, req.VolumeId)
+	}
+	defer s.volumeLocks.Release(req.VolumeId)
+
+	s.idLocker.Lock(req.VolumeId)
+	defer s.idLocker.Unlock(req.VolumeId)
+
+	if _, err := os.Stat(volumePath(req.VolumeId)); err != nil {
+		return nil, status.Error(codes.NotFound, "Volume not found")
+	}
+
+	return &csi.NodeExpandVolumeResponse{
+		CapacityBytes: req.CapacityRange.RequiredBytes,
+	}, nil
+}
+
+func (s *Server) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetCapabilitiesRequest) (*csi.NodeGetCapabilitiesResponse, error) {
+	return &csi.NodeGetCapabilitiesResponse{
+		Capabilities: []*csi.NodeServiceCapability{
+			{
+				Type: &csi.NodeServiceCapability_Rpc{
+					Rpc: &csi.NodeServiceCapability_RPC{
+						Type: csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
+					},
+				},
+			},
+			{
+				Type: &csi.NodeServiceCapability_Rpc{
+					Rpc: &csi.NodeServiceCapability_RPC{
+						Type: csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
+					},
+				},
+			},
+			{
+				Type: &csi.NodeServiceCapability_Rpc{
+					Rpc: &csi.NodeServiceCapability_RPC{
+						Type: csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
+					},
+				},
+			},
+			{
+				Type: &csi.NodeServiceCapability_Rpc{
+					Rpc: &csi.NodeServiceCapability_RPC{
+						Type: csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *Server) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoRequest) (*csi.NodeGetInfoResponse, error) {
+	return &csi.NodeGetInfoResponse{
+		NodeId:            s.nodeID,
+		MaxVolumesPerNode: 256, // Reasonable default
+		AccessibleTopology: &csi.Topology{
+			Segments: map[string]string{
+				"kubernetes.io/hostname": s.nodeID,
+			},
+		},
+	}, nil
+}
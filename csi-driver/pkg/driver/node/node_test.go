@@ -0,0 +1,111 @@
+package node
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+type fakeMounter struct {
+	mounted   map[string]string
+	statfsErr map[string]error
+}
+
+func newFakeMounter() *fakeMounter {
+	return &fakeMounter{
+		mounted:   make(map[string]string),
+		statfsErr: make(map[string]error),
+	}
+}
+
+func (f *fakeMounter) Mount(source, target, fstype string, opts []string) error {
+	f.mounted[target] = source
+	delete(f.statfsErr, target)
+	return nil
+}
+
+func (f *fakeMounter) Unmount(target string) error {
+	delete(f.mounted, target)
+	return nil
+}
+
+func (f *fakeMounter) Statfs(target string) error {
+	return f.statfsErr[target]
+}
+
+func newTestServer(ephemeral bool) (*Server, *fakeMounter) {
+	fm := newFakeMounter()
+	return NewServer("test-node", ephemeral, fm), fm
+}
+
+func TestNodePublishVolume_ControllerProvisioned(t *testing.T) {
+	s, _ := newTestServer(false)
+
+	volID := "vol-a"
+	volPath := volumePath(volID)
+	if err := os.MkdirAll(volPath, 0750); err != nil {
+		t.Fatalf("seed volume directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(volPath) })
+
+	target := t.TempDir()
+	if _, err := s.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+		VolumeId:   volID,
+		TargetPath: target,
+	}); err != nil {
+		t.Fatalf("NodePublishVolume: %v", err)
+	}
+
+	s.mountLock.RLock()
+	mount, exists := s.mounts[target]
+	s.mountLock.RUnlock()
+	if !exists {
+		t.Fatal("expected mount to be recorded")
+	}
+	if mount.Ephemeral {
+		t.Fatal("controller-provisioned mount must not be marked ephemeral")
+	}
+
+	if _, err := s.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{
+		VolumeId:   volID,
+		TargetPath: target,
+	}); err != nil {
+		t.Fatalf("NodeUnpublishVolume: %v", err)
+	}
+
+	if _, err := os.Stat(volPath); err != nil {
+		t.Fatalf("controller-provisioned volume must survive NodeUnpublishVolume: %v", err)
+	}
+}
+
+func TestNodePublishVolume_Ephemeral(t *testing.T) {
+	s, _ := newTestServer(true)
+
+	volID := "ephemeral-vol-a"
+	volPath := volumePath(volID)
+	target := t.TempDir()
+
+	if _, err := s.NodePublishVolume(context.Background(), &csi.NodePublishVolumeRequest{
+		VolumeId:   volID,
+		TargetPath: target,
+	}); err != nil {
+		t.Fatalf("NodePublishVolume: %v", err)
+	}
+
+	if _, err := os.Stat(volPath); err != nil {
+		t.Fatalf("expected ephemeral volume directory to exist: %v", err)
+	}
+
+	if _, err := s.NodeUnpublishVolume(context.Background(), &csi.NodeUnpublishVolumeRequest{
+		VolumeId:   volID,
+		TargetPath: target,
+	}); err != nil {
+		t.Fatalf("NodeUnpublishVolume: %v", err)
+	}
+
+	if _, err := os.Stat(volPath); !os.IsNotExist(err) {
+		t.Fatalf("expected ephemeral volume directory to be deleted, got err=%v", err)
+	}
+}
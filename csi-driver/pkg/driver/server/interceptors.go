@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/kubernetes-csi/csi-lib-utils/protosanitizer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// loggingInterceptor logs each RPC's method, request, and response, with
+// fields tagged as CSI secrets (e.g. req.Secrets) redacted via
+// protosanitizer before anything is logged.
+func loggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		klog.V(3).Infof("GRPC call: %s", info.FullMethod)
+		klog.V(5).Infof("GRPC request: %s", protosanitizer.StripSecrets(req))
+
+		resp, err := handler(ctx, req)
+
+		if err != nil {
+			klog.Errorf("GRPC error: %v", err)
+		} else {
+			klog.V(5).Infof("GRPC response: %s", protosanitizer.StripSecrets(resp))
+		}
+		return resp, err
+	}
+}
+
+// metricsInterceptor records each RPC's latency in rpcDurationSeconds,
+// labeled by CSI method and the gRPC status code it returned.
+func metricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		rpcDurationSeconds.WithLabelValues(info.FullMethod, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// recoveryInterceptor converts a panic in any handler into a codes.Internal
+// error instead of crashing the process, logging the stack so the panic is
+// still diagnosable.
+func recoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				klog.Errorf("panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+				err = status.Errorf(codes.Internal, "panic in %s: %v", info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
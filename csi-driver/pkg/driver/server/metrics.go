@@ -0,0 +1,29 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// rpcDurationSeconds measures CSI RPC latency, labeled by method and the
+// gRPC status code returned, so a sidecar retry storm or a slow backend
+// shows up per-method rather than as a single aggregate number.
+var rpcDurationSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "dfs_csi_rpc_duration_seconds",
+		Help:    "Latency of CSI gRPC calls, labeled by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"method", "code"},
+)
+
+func init() {
+	prometheus.MustRegister(rpcDurationSeconds)
+}
+
+// MetricsHandler returns the HTTP handler to mount on --metrics-addr.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,110 @@
+// Package server implements a non-blocking CSI gRPC server, modeled on the
+// NonBlockingGRPCServer used by kubernetes-csi/csi-driver-host-path: it
+// starts the listener in a background goroutine so the caller can continue
+// setup (e.g. starting a metrics HTTP server) before blocking on Wait, and
+// it chains interceptors for structured logging, latency metrics, and panic
+// recovery around every RPC.
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"google.golang.org/grpc"
+	"k8s.io/klog/v2"
+)
+
+// NonBlockingGRPCServer serves the CSI Identity, Controller, and Node
+// services without blocking its caller until Wait is called.
+type NonBlockingGRPCServer struct {
+	wg     sync.WaitGroup
+	server *grpc.Server
+}
+
+// NewNonBlockingGRPCServer returns an idle server; call Start to serve.
+func NewNonBlockingGRPCServer() *NonBlockingGRPCServer {
+	return &NonBlockingGRPCServer{}
+}
+
+// Start listens on endpoint and begins serving ids, cs, and ns in a
+// background goroutine. cs and ns may be nil, e.g. for a Node-only process,
+// in which case that service is not registered.
+func (s *NonBlockingGRPCServer) Start(endpoint string, ids csi.IdentityServer, cs csi.ControllerServer, ns csi.NodeServer) error {
+	scheme, addr, err := parseEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen(scheme, addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", endpoint, err)
+	}
+
+	// recoveryInterceptor must be innermost (closest to the handler): if it
+	// ran outside metricsInterceptor, a panicking handler would unwind past
+	// the metrics call before rpcDurationSeconds ever observed it, hiding
+	// exactly the failures the metric exists to surface.
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		loggingInterceptor(),
+		metricsInterceptor(),
+		recoveryInterceptor(),
+	))
+	s.server = srv
+
+	csi.RegisterIdentityServer(srv, ids)
+	if cs != nil {
+		csi.RegisterControllerServer(srv, cs)
+	}
+	if ns != nil {
+		csi.RegisterNodeServer(srv, ns)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		klog.Infof("Listening for connections on %s", listener.Addr())
+		if err := srv.Serve(listener); err != nil {
+			klog.Errorf("GRPC server exited: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Wait blocks until the server started by Start has stopped serving.
+func (s *NonBlockingGRPCServer) Wait() {
+	s.wg.Wait()
+}
+
+// Stop gracefully drains in-flight RPCs before shutting down.
+func (s *NonBlockingGRPCServer) Stop() {
+	if s.server != nil {
+		s.server.GracefulStop()
+	}
+}
+
+// ForceStop shuts down immediately, terminating any in-flight RPCs.
+func (s *NonBlockingGRPCServer) ForceStop() {
+	if s.server != nil {
+		s.server.Stop()
+	}
+}
+
+func parseEndpoint(endpoint string) (string, string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("could not parse endpoint: %v", err)
+	}
+
+	var addr string
+	if u.Host == "" {
+		addr = u.Path
+	} else {
+		addr = u.Host
+	}
+
+	return u.Scheme, addr, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
@@ -12,10 +13,18 @@ import (
 	"syscall"
 )
 
+// nodeHeartbeat is the payload returned by /nodes, polled by the CSI
+// controller to learn which nodes are reachable and their free capacity for
+// topology-aware provisioning.
+type nodeHeartbeat struct {
+	NodeID    string `json:"node_id"`
+	FreeBytes int64  `json:"free_bytes"`
+}
+
 type StorageNode struct {
-	nodeID    string
-	dataDir   string
-	server    *http.Server
+	nodeID  string
+	dataDir string
+	server  *http.Server
 }
 
 func NewStorageNode(nodeID, dataDir string) (*StorageNode, error) {
@@ -34,6 +43,7 @@ func (n *StorageNode) Start(port int) error {
 	mux.HandleFunc("/ready", n.handleReady)
 	mux.HandleFunc("/health", n.handleHealth)
 	mux.HandleFunc("/volumes", n.handleVolumes)
+	mux.HandleFunc("/nodes", n.handleNodes)
 
 	n.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
@@ -97,6 +107,29 @@ func (n *StorageNode) handleVolumes(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleNodes reports this node's identity and free capacity. The CSI
+// controller polls it as a heartbeat to build its topology registry for
+// AccessibleTopology-aware provisioning.
+func (n *StorageNode) handleNodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(n.dataDir, &stat); err != nil {
+		http.Error(w, fmt.Sprintf("failed to stat data directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	freeBytes := int64(stat.Bavail) * int64(stat.Bsize)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(nodeHeartbeat{
+		NodeID:    n.nodeID,
+		FreeBytes: freeBytes,
+	})
+}
+
 func main() {
 	var (
 		port    = flag.Int("port", 8080, "Port to listen on")